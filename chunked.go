@@ -0,0 +1,156 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Transfer-Encoding: chunked
+//
+// Chunked-Body   = *chunk
+//                  last-chunk
+//                  trailer
+//                  CRLF
+//
+// chunk          = chunk-size CRLF
+//                  chunk-data CRLF
+// chunk-size     = 1*HEX
+// last-chunk     = 1*("0") CRLF
+// trailer        = *(entity-header CRLF)
+
+// chunkedReader decodes an HTTP/1.1 chunked transfer-encoded body read
+// from r into a plain byte stream. Once the terminating zero-size chunk
+// is consumed, any trailer headers are parsed and made available via
+// Trailer.
+type chunkedReader struct {
+	r         *bufio.Reader
+	chunkLeft int64
+	trailer   Headers
+	err       error
+}
+
+func newChunkedReader(r *bufio.Reader) *chunkedReader {
+	return &chunkedReader{r: r}
+}
+
+// Trailer returns the trailer headers sent after the final chunk. It is
+// only populated once Read has returned io.EOF.
+func (cr *chunkedReader) Trailer() Headers {
+	return cr.trailer
+}
+
+func (cr *chunkedReader) Read(p []byte) (int, error) {
+	if cr.err != nil {
+		return 0, cr.err
+	}
+
+	if cr.chunkLeft == 0 {
+		if err := cr.nextChunk(); err != nil {
+			cr.err = err
+			return 0, err
+		}
+
+		if cr.chunkLeft == 0 {
+			cr.err = io.EOF
+			return 0, io.EOF
+		}
+	}
+
+	if int64(len(p)) > cr.chunkLeft {
+		p = p[:cr.chunkLeft]
+	}
+
+	n, err := cr.r.Read(p)
+	cr.chunkLeft -= int64(n)
+	if err != nil {
+		cr.err = err
+		return n, err
+	}
+
+	if cr.chunkLeft == 0 {
+		if _, _, err := cr.r.ReadLine(); err != nil {
+			cr.err = err
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+// nextChunk reads the next "chunk-size CRLF" line and, on the last
+// chunk, the trailer headers that follow it.
+func (cr *chunkedReader) nextChunk() error {
+	l, _, err := cr.r.ReadLine()
+	if err != nil {
+		return err
+	}
+
+	sizeStr := string(l)
+	if i := strings.IndexByte(sizeStr, ';'); i >= 0 {
+		// chunk extensions are not supported, only the size is used
+		sizeStr = sizeStr[:i]
+	}
+	sizeStr = strings.TrimSpace(sizeStr)
+
+	size, err := strconv.ParseUint(sizeStr, 16, 63)
+	if err != nil {
+		return fmt.Errorf("invalid chunk size: %w", err)
+	}
+	cr.chunkLeft = int64(size)
+
+	if size == 0 {
+		trailer, err := newHeaderReader(cr.r, headerLimits{}).ReadMIMEHeader()
+		if err != nil {
+			return err
+		}
+		cr.trailer = trailer
+	}
+
+	return nil
+}
+
+// writeChunk writes p as a single chunk: hex-size CRLF, the bytes
+// themselves, then a trailing CRLF. Empty chunks are a no-op since a
+// zero-size chunk marks the end of the body.
+func writeChunk(w io.Writer, p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+
+	if _, err := fmt.Fprintf(w, "%x\r\n", len(p)); err != nil {
+		return 0, err
+	}
+
+	n, err := w.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	if _, err := w.Write([]byte("\r\n")); err != nil {
+		return n, err
+	}
+
+	return n, nil
+}
+
+// writeChunkedTrailer writes the terminating zero-size chunk followed
+// by the given trailer headers (which may be empty) and the final CRLF.
+func writeChunkedTrailer(w io.Writer, trailer Headers) error {
+	if _, err := io.WriteString(w, "0\r\n"); err != nil {
+		return err
+	}
+
+	for k, v := range trailer {
+		for _, vv := range v {
+			if _, err := fmt.Fprintf(w, "%s: %s\r\n", k, vv); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err := io.WriteString(w, "\r\n")
+	return err
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"io"
+	"strings"
+)
+
+// continueState is shared between a request's Body and the Response
+// written for it, so that whichever happens first - the handler
+// reading the body, or the handler writing a final status without
+// ever reading it - determines whether an interim "100 Continue" is
+// sent at all.
+type continueState struct {
+	sent      bool
+	responded bool
+}
+
+// maybeSendContinue writes "100 Continue" to w, unless it was already
+// sent or a final status has already been written for this request.
+func (cs *continueState) maybeSendContinue(w io.Writer) error {
+	if cs.sent || cs.responded {
+		return nil
+	}
+	cs.sent = true
+
+	_, err := io.WriteString(w, "HTTP/1.1 100 Continue\r\n\r\n")
+	return err
+}
+
+// expectContinueReader delays sending "100 Continue" until the body is
+// actually read, per RFC 7231 §5.1.1.
+type expectContinueReader struct {
+	r     io.Reader
+	w     io.Writer
+	state *continueState
+}
+
+func (cr *expectContinueReader) Read(p []byte) (int, error) {
+	if err := cr.state.maybeSendContinue(cr.w); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(p)
+}
+
+// wrapExpectContinue wraps req.Body so its first Read sends an interim
+// "100 Continue" response on w, if the request carries an
+// Expect: 100-continue header. It is a no-op otherwise.
+func wrapExpectContinue(req *Request, w io.Writer) {
+	if req.Body == nil {
+		return
+	}
+
+	ev, ok := req.Headers["Expect"]
+	if !ok {
+		return
+	}
+
+	wantsContinue := false
+	for _, v := range ev {
+		if strings.EqualFold(strings.TrimSpace(v), "100-continue") {
+			wantsContinue = true
+			break
+		}
+	}
+	if !wantsContinue {
+		return
+	}
+
+	cs := &continueState{}
+	req.continueState = cs
+	req.Body = &expectContinueReader{r: req.Body, w: w, state: cs}
+}
+
+// WriteContinue sends an interim "100 Continue" response, for a
+// handler that wants to signal readiness for the body before it has
+// read from req.Body (which would otherwise trigger it lazily). It has
+// no effect if the request had no Expect: 100-continue header, or if a
+// 100 Continue was already sent.
+func (r *Response) WriteContinue() error {
+	if r.continueState == nil {
+		return nil
+	}
+	return r.continueState.maybeSendContinue(r.w)
+}
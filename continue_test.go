@@ -0,0 +1,27 @@
+package main
+
+import "testing"
+
+func TestKeepAliveClosesWhenContinueNeverSent(t *testing.T) {
+	req := &Request{
+		Proto:         "HTTP/1.1",
+		Headers:       Headers{},
+		continueState: &continueState{responded: true, sent: false},
+	}
+
+	if keepAlive(req) {
+		t.Fatal("expected keepAlive to force-close when handler responded without sending 100-continue")
+	}
+}
+
+func TestKeepAliveUnaffectedWhenContinueWasSent(t *testing.T) {
+	req := &Request{
+		Proto:         "HTTP/1.1",
+		Headers:       Headers{},
+		continueState: &continueState{responded: true, sent: true},
+	}
+
+	if !keepAlive(req) {
+		t.Fatal("expected normal HTTP/1.1 keep-alive once 100-continue was sent")
+	}
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/url"
+)
+
+var (
+	// ErrNotMultipart is returned by ParseMultipartForm when the
+	// request's Content-Type isn't multipart/form-data.
+	ErrNotMultipart = errors.New("httpg: request Content-Type isn't multipart/form-data")
+	// ErrMissingBoundary is returned by ParseMultipartForm when the
+	// Content-Type has no boundary parameter.
+	ErrMissingBoundary = errors.New("httpg: no multipart boundary param in Content-Type")
+	// ErrMissingFile is returned by FormFile when no file was
+	// submitted for the given form field.
+	ErrMissingFile = errors.New("httpg: no such file in multipart form")
+)
+
+// ParseForm populates req.Form from the request URL's query string
+// and, for a POST/PUT/PATCH with an
+// application/x-www-form-urlencoded body, from the body too (in which
+// case those values are also recorded separately in req.PostForm).
+// It is a no-op if req.Form is already populated.
+func (req *Request) ParseForm() error {
+	if req.Form != nil {
+		return nil
+	}
+
+	req.Form = url.Values{}
+	if req.Url != nil {
+		for k, v := range req.Url.Query() {
+			req.Form[k] = append(req.Form[k], v...)
+		}
+	}
+
+	switch req.Method {
+	case "POST", "PUT", "PATCH":
+	default:
+		return nil
+	}
+
+	mediaType, _, err := mime.ParseMediaType(req.Headers.Get("Content-Type"))
+	if err != nil || mediaType != "application/x-www-form-urlencoded" {
+		return nil
+	}
+	if req.Body == nil {
+		return nil
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return err
+	}
+
+	vals, err := url.ParseQuery(string(body))
+	if err != nil {
+		return err
+	}
+
+	req.PostForm = vals
+	for k, v := range vals {
+		req.Form[k] = append(req.Form[k], v...)
+	}
+
+	return nil
+}
+
+// ParseMultipartForm parses a multipart/form-data request body into
+// req.MultipartForm, spilling any file part larger than maxMemory
+// bytes to a temp file (see FileHeader.Open). Plain field values are
+// also merged into req.Form. It is a no-op if req.MultipartForm is
+// already populated.
+func (req *Request) ParseMultipartForm(maxMemory int64) error {
+	if req.MultipartForm != nil {
+		return nil
+	}
+
+	mediaType, params, err := mime.ParseMediaType(req.Headers.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		return ErrNotMultipart
+	}
+	boundary, ok := params["boundary"]
+	if !ok || boundary == "" {
+		return ErrMissingBoundary
+	}
+	if req.Body == nil {
+		return errors.New("httpg: request has no body")
+	}
+
+	mr := newMultipartReader(req.Body, boundary)
+	form := &Form{
+		Value: make(map[string][]string),
+		File:  make(map[string][]*FileHeader),
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := part.FormName()
+		if name == "" {
+			continue
+		}
+
+		if filename := part.FileName(); filename != "" {
+			fh, err := newFileHeader(part, filename, maxMemory)
+			if err != nil {
+				return err
+			}
+			form.File[name] = append(form.File[name], fh)
+			continue
+		}
+
+		data, err := io.ReadAll(part)
+		if err != nil {
+			return err
+		}
+		form.Value[name] = append(form.Value[name], string(data))
+	}
+
+	req.MultipartForm = form
+
+	if req.Form == nil {
+		req.Form = url.Values{}
+	}
+	for k, v := range form.Value {
+		req.Form[k] = append(req.Form[k], v...)
+	}
+
+	return nil
+}
+
+// FormFile returns the first file submitted for the given form field,
+// calling ParseMultipartForm with a 32MB memory limit first if it
+// hasn't already been called.
+func (req *Request) FormFile(name string) (io.ReadCloser, *FileHeader, error) {
+	if req.MultipartForm == nil {
+		if err := req.ParseMultipartForm(32 << 20); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	fhs := req.MultipartForm.File[name]
+	if len(fhs) == 0 {
+		return nil, nil, ErrMissingFile
+	}
+
+	f, err := fhs[0].Open()
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, fhs[0], nil
+}
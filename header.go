@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrLineTooLong is returned when a request line, a single header
+	// line, or a header value continued across obs-fold lines exceeds
+	// its configured limit.
+	ErrLineTooLong = errors.New("httpg: header line too long")
+	// ErrHeaderTooLong is returned when a request has more header
+	// lines than its configured limit.
+	ErrHeaderTooLong = errors.New("httpg: too many header lines")
+)
+
+// headerLimits bounds how much of a request's header section a
+// headerReader will accept before giving up with a typed error.
+// Zero means unlimited for each field.
+type headerLimits struct {
+	maxLineLength  int
+	maxValueLength int
+	maxHeaderLines int
+}
+
+// headerReader reads a request line and header section off a
+// *bufio.Reader in the style of net/textproto.Reader: it understands
+// RFC 7230 obs-fold continuations and enforces headerLimits, returning
+// ErrLineTooLong / ErrHeaderTooLong instead of silently truncating the
+// way bufio.Reader.ReadLine does.
+type headerReader struct {
+	r      *bufio.Reader
+	limits headerLimits
+}
+
+func newHeaderReader(r *bufio.Reader, limits headerLimits) *headerReader {
+	return &headerReader{r: r, limits: limits}
+}
+
+// ReadLine reads a single, non-folded line (the request line).
+func (hr *headerReader) ReadLine() (string, error) {
+	l, err := hr.readRawLine()
+	if err != nil {
+		return "", err
+	}
+	return string(l), nil
+}
+
+// ReadMIMEHeader reads header lines, folding obs-fold continuations
+// into their preceding value, until it hits the blank line that ends
+// the header section. Header names are canonicalized to MIME form and
+// values are kept raw (not comma-split) so a caller that needs
+// list-valued semantics can split deliberately with splitHeaderList.
+func (hr *headerReader) ReadMIMEHeader() (Headers, error) {
+	h := make(Headers)
+
+	var lines int
+	for {
+		line, err := hr.readFoldedLine()
+		if err != nil {
+			return h, err
+		}
+		if line == "" {
+			break
+		}
+
+		lines++
+		if hr.limits.maxHeaderLines > 0 && lines > hr.limits.maxHeaderLines {
+			return h, ErrHeaderTooLong
+		}
+
+		key, value := parseHeaderLine(line)
+		h.Add(key, value)
+	}
+
+	return h, nil
+}
+
+// readFoldedLine reads one logical header line: the initial line plus
+// any following lines that start with SP/HTAB, which RFC 7230 §3.2.4
+// calls obs-fold and treats as part of the previous line's value.
+func (hr *headerReader) readFoldedLine() (string, error) {
+	l, err := hr.readRawLine()
+	if err != nil {
+		return "", err
+	}
+	if len(l) == 0 {
+		return "", nil
+	}
+
+	if !hr.foldedContinuationFollows() {
+		return string(l), nil
+	}
+
+	var sb bytes.Buffer
+	sb.Write(l)
+
+	for hr.foldedContinuationFollows() {
+		cont, err := hr.readRawLine()
+		if err != nil {
+			return "", err
+		}
+
+		sb.WriteByte(' ')
+		sb.Write(bytes.TrimLeft(cont, " \t"))
+
+		if hr.limits.maxValueLength > 0 && sb.Len() > hr.limits.maxValueLength {
+			return "", ErrLineTooLong
+		}
+	}
+
+	return sb.String(), nil
+}
+
+func (hr *headerReader) foldedContinuationFollows() bool {
+	b, err := hr.r.Peek(1)
+	if err != nil {
+		return false
+	}
+	return b[0] == ' ' || b[0] == '\t'
+}
+
+// readRawLine reads a single CRLF-terminated line, reassembling it if
+// bufio.Reader had to split it across several internal reads, and
+// enforces maxLineLength as it goes - a line with no CRLF at all must
+// not be buffered past the limit while waiting for one that may never
+// come.
+func (hr *headerReader) readRawLine() ([]byte, error) {
+	l, isPrefix, err := hr.r.ReadLine()
+	if err != nil {
+		return nil, err
+	}
+
+	if hr.limits.maxLineLength > 0 && len(l) > hr.limits.maxLineLength {
+		return nil, ErrLineTooLong
+	}
+
+	if !isPrefix {
+		return l, nil
+	}
+
+	full := append([]byte(nil), l...)
+	for isPrefix {
+		var more []byte
+		more, isPrefix, err = hr.r.ReadLine()
+		if err != nil {
+			return nil, err
+		}
+
+		full = append(full, more...)
+		if hr.limits.maxLineLength > 0 && len(full) > hr.limits.maxLineLength {
+			return nil, ErrLineTooLong
+		}
+	}
+
+	return full, nil
+}
+
+// parseHeaderLine splits a single "name: value" header line, trims
+// surrounding whitespace, and canonicalizes the name to MIME form
+// (e.g. "content-type" -> "Content-Type") so lookups are independent
+// of how the client cased the header.
+func parseHeaderLine(l string) (string, string) {
+	i := strings.IndexByte(l, ':')
+	if i < 0 {
+		return canonicalHeaderKey(strings.TrimSpace(l)), ""
+	}
+
+	key := canonicalHeaderKey(strings.TrimSpace(l[:i]))
+	value := strings.TrimSpace(l[i+1:])
+	return key, value
+}
+
+// canonicalHeaderKey upper-cases the first letter and every letter
+// following a '-', lower-casing the rest, mirroring
+// textproto.CanonicalMIMEHeaderKey.
+func canonicalHeaderKey(s string) string {
+	b := []byte(s)
+	upper := true
+	for i, c := range b {
+		switch {
+		case c == '-':
+			upper = true
+		case upper && 'a' <= c && c <= 'z':
+			b[i] = c - ('a' - 'A')
+			upper = false
+		case !upper && 'A' <= c && c <= 'Z':
+			b[i] = c + ('a' - 'A')
+			upper = false
+		default:
+			upper = false
+		}
+	}
+	return string(b)
+}
+
+// splitHeaderList splits the comma-separated elements of a list-valued
+// header, per RFC 7230 §3.2.6 (e.g. Accept, Transfer-Encoding). Never
+// use this for a header whose value may itself contain commas outside
+// that list syntax, most notably Set-Cookie.
+func splitHeaderList(value string) []string {
+	parts := strings.Split(value, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestReadMIMEHeaderJoinsObsFold(t *testing.T) {
+	raw := "X-Long: part-one\r\n" +
+		" part-two\r\n" +
+		"\tpart-three\r\n" +
+		"\r\n"
+
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader(raw)), headerLimits{})
+	h, err := hr.ReadMIMEHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "part-one part-two part-three"
+	if got := h.Get("X-Long"); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestReadMIMEHeaderCanonicalizesKeys(t *testing.T) {
+	raw := "content-TYPE: text/plain\r\n\r\n"
+
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader(raw)), headerLimits{})
+	h, err := hr.ReadMIMEHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := h.Get("Content-Type"); got != "text/plain" {
+		t.Fatalf("got %q, want %q", got, "text/plain")
+	}
+}
+
+func TestReadMIMEHeaderMaxHeaderLines(t *testing.T) {
+	raw := "A: 1\r\nB: 2\r\nC: 3\r\n\r\n"
+
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader(raw)), headerLimits{maxHeaderLines: 2})
+	_, err := hr.ReadMIMEHeader()
+	if !errors.Is(err, ErrHeaderTooLong) {
+		t.Fatalf("got err %v, want ErrHeaderTooLong", err)
+	}
+}
+
+func TestReadMIMEHeaderMaxValueLength(t *testing.T) {
+	raw := "X-Long: part-one\r\n" +
+		" part-two-that-pushes-the-folded-value-past-the-limit\r\n" +
+		"\r\n"
+
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader(raw)), headerLimits{maxValueLength: 20})
+	_, err := hr.ReadMIMEHeader()
+	if !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("got err %v, want ErrLineTooLong", err)
+	}
+}
+
+// TestReadRawLineBoundsUnterminatedLine reproduces a bug where a line
+// with no CRLF at all was buffered in full - however large - before
+// maxLineLength was ever checked, defeating the limit's purpose of
+// bounding memory use. It must fail fast instead of reading to EOF.
+func TestReadRawLineBoundsUnterminatedLine(t *testing.T) {
+	huge := strings.Repeat("x", 10<<20) // 10MB, no CRLF anywhere
+
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader(huge)), headerLimits{maxLineLength: 100})
+	_, err := hr.readRawLine()
+	if !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("got err %v, want ErrLineTooLong", err)
+	}
+}
+
+func TestReadRawLineMaxLineLength(t *testing.T) {
+	raw := strings.Repeat("x", 200) + "\r\nrest\r\n"
+
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader(raw)), headerLimits{maxLineLength: 100})
+	_, err := hr.readRawLine()
+	if !errors.Is(err, ErrLineTooLong) {
+		t.Fatalf("got err %v, want ErrLineTooLong", err)
+	}
+}
+
+func TestReadRawLineWithinLimit(t *testing.T) {
+	raw := "short line\r\n"
+
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader(raw)), headerLimits{maxLineLength: 100})
+	l, err := hr.readRawLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(l) != "short line" {
+		t.Fatalf("got %q, want %q", l, "short line")
+	}
+}
+
+// TestReadRawLineFinalLineWithoutCRLF documents bufio.Reader.ReadLine's
+// own behavior, which readRawLine inherits: a final line at EOF with no
+// trailing CRLF is still returned as a line rather than as an error.
+func TestReadRawLineFinalLineWithoutCRLF(t *testing.T) {
+	hr := newHeaderReader(bufio.NewReader(strings.NewReader("no newline here")), headerLimits{})
+	l, err := hr.readRawLine()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(l) != "no newline here" {
+		t.Fatalf("got %q, want %q", l, "no newline here")
+	}
+
+	if _, err := hr.readRawLine(); !errors.Is(err, io.EOF) {
+		t.Fatalf("got err %v, want io.EOF on next call", err)
+	}
+}
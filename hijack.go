@@ -0,0 +1,38 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net"
+)
+
+var (
+	// ErrNotHijackable is returned by Hijack when the Response wasn't
+	// created with an underlying connection (e.g. one built by hand
+	// in a test).
+	ErrNotHijackable = errors.New("httpg: connection not hijackable")
+	// ErrAlreadyHijacked is returned by Hijack when called more than
+	// once for the same Response.
+	ErrAlreadyHijacked = errors.New("httpg: connection already hijacked")
+)
+
+// Hijack lets the caller take over the connection, for protocols such
+// as WebSockets or CONNECT tunnels that switch away from HTTP. It
+// returns the underlying net.Conn and a *bufio.ReadWriter wrapping the
+// same buffered reader the server used to parse the request, so no
+// already-buffered bytes are lost. After a successful Hijack, the
+// Server no longer writes a status line for this request or reads
+// another request off the connection; the caller owns it, including
+// closing it when done.
+func (r *Response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if r.conn == nil {
+		return nil, nil, ErrNotHijackable
+	}
+	if r.hijacked {
+		return nil, nil, ErrAlreadyHijacked
+	}
+	r.hijacked = true
+
+	bw := bufio.NewWriter(r.conn)
+	return r.conn, bufio.NewReadWriter(r.reader, bw), nil
+}
@@ -12,6 +12,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Request       = Request-Line
@@ -112,21 +113,80 @@ func (h Headers) Set(key, value string) {
 	h[key] = []string{value}
 }
 
+// Get returns the first value associated with key, or "" if there is
+// none. key must already be in canonical MIME form (see
+// canonicalHeaderKey) to match what parseRequest stores.
+func (h Headers) Get(key string) string {
+	v, ok := h[key]
+	if !ok || len(v) == 0 {
+		return ""
+	}
+	return v[0]
+}
+
 type Request struct {
 	Method  string
 	Url     *url.URL
 	Proto   string
 	Headers Headers
 	Body    io.Reader
+
+	// Form holds the query-string and, after ParseForm, the
+	// application/x-www-form-urlencoded body values combined. PostForm
+	// holds only the body values. Both are nil until ParseForm is
+	// called.
+	Form     url.Values
+	PostForm url.Values
+	// MultipartForm holds the parsed multipart/form-data body after a
+	// call to ParseMultipartForm.
+	MultipartForm *Form
+
+	pathValues    map[string]string
+	continueState *continueState
+}
+
+// PathValue returns the value captured for the "{name}" segment of the
+// pattern the ServeMux matched this request against, or "" if there is
+// no such capture (or the request wasn't routed through a ServeMux).
+func (req *Request) PathValue(name string) string {
+	return req.pathValues[name]
 }
 
 type Response struct {
-	Headers    Headers
-	statusCode int
-	w          io.Writer
+	Headers Headers
+	// Trailer holds header values to send after the final chunk when
+	// the response body is written with chunked transfer-encoding.
+	Trailer Headers
+
+	statusCode    int
+	w             io.Writer
+	headerWritten bool
+	chunked       bool
+	bodyClosed    bool
+
+	// conn and reader back the Hijack method; conn is nil when the
+	// Response wasn't created by the Server (e.g. in tests).
+	conn     net.Conn
+	reader   *bufio.Reader
+	hijacked bool
+
+	// continueState is shared with the Request's Body; see WriteContinue.
+	continueState *continueState
 }
 
-func (r *Response) Write(body []byte) (int, error) {
+// WriteHeader writes the status line and headers. If no Content-Length
+// has been set, the response switches to chunked transfer-encoding so
+// handlers can stream a body of unknown length via BodyWriter. Calling
+// WriteHeader more than once has no effect.
+func (r *Response) WriteHeader() error {
+	if r.headerWritten {
+		return nil
+	}
+	r.headerWritten = true
+	if r.continueState != nil {
+		r.continueState.responded = true
+	}
+
 	statusCode := r.statusCode
 	reason, ok := httpStatusCodes[statusCode]
 	if !ok {
@@ -134,10 +194,14 @@ func (r *Response) Write(body []byte) (int, error) {
 		reason = httpStatusCodes[statusCode]
 	}
 
+	if _, ok := r.Headers["Content-Length"]; !ok {
+		r.chunked = true
+		r.Headers.Set("Transfer-Encoding", "chunked")
+	}
+
 	// status line
-	n, err := r.w.Write([]byte(fmt.Sprintf("HTTP/1.1 %d %s \r\n", statusCode, reason)))
-	if err != nil {
-		return n, err
+	if _, err := r.w.Write([]byte(fmt.Sprintf("HTTP/1.1 %d %s \r\n", statusCode, reason))); err != nil {
+		return err
 	}
 
 	// headers
@@ -163,71 +227,135 @@ func (r *Response) Write(body []byte) (int, error) {
 	}
 
 	// headers / body delimeter
-	n, err = r.w.Write([]byte("\r\n"))
+	_, err := r.w.Write([]byte("\r\n"))
+	return err
+}
 
-	// body
-	r.w.Write(body)
+// Write writes the status line and headers if they haven't been sent
+// yet, then writes body as the (or, for a chunked response, one more)
+// body chunk. Write may be called more than once, exactly like any
+// other io.Writer; for a chunked response, Close (which the Server
+// calls automatically once the handler returns) is what terminates the
+// body, not Write.
+func (r *Response) Write(body []byte) (int, error) {
+	if !r.headerWritten {
+		if err := r.WriteHeader(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.chunked {
+		return writeChunk(r.w, body)
+	}
 
-	return n, err
+	return r.w.Write(body)
 }
 
-func (r *Response) StatusCode(code int) {
-	r.statusCode = code
+// responseBodyWriter adapts a Response into an io.Writer suitable for
+// streaming handlers: every Write call is emitted as its own chunk when
+// the response is chunked, or written straight through otherwise.
+type responseBodyWriter struct {
+	r *Response
 }
 
-func main() {
-	ln, err := net.Listen("tcp", ":8080")
-	if err != nil {
-		log.Fatal(err)
+func (w responseBodyWriter) Write(p []byte) (int, error) {
+	if w.r.chunked {
+		return writeChunk(w.r.w, p)
 	}
-	defer ln.Close()
+	return w.r.w.Write(p)
+}
 
-	for {
-		conn, err := ln.Accept()
-		if err != nil {
-			log.Fatal(err)
+// BodyWriter returns an io.Writer for streaming a response body across
+// multiple writes. It sends the headers (if not already sent) on first
+// use. The caller must call Close once done to terminate a chunked
+// body; it is a no-op for a response with a Content-Length.
+func (r *Response) BodyWriter() io.Writer {
+	if !r.headerWritten {
+		r.WriteHeader()
+	}
+	return responseBodyWriter{r}
+}
+
+// Close terminates a chunked response body, writing the final zero-size
+// chunk and any Trailer headers. It has no effect on a response with a
+// Content-Length, and is safe to call more than once (including after
+// Write already closed the body itself) - the terminator is written
+// exactly once.
+func (r *Response) Close() error {
+	if !r.headerWritten {
+		if err := r.WriteHeader(); err != nil {
+			return err
 		}
+	}
 
-		go handleConnection(conn)
+	if !r.chunked {
+		return nil
 	}
-}
 
-func handleConnection(conn net.Conn) {
-	defer conn.Close()
+	return r.closeChunked()
+}
 
-	_, err := parseRequest(conn)
-	if err != nil {
-		log.Fatal(err)
+// closeChunked writes the terminating zero-size chunk and Trailer
+// headers, but only the first time it's called for this Response -
+// Write and Close both end up here, and a handler may call either (or
+// both).
+func (r *Response) closeChunked() error {
+	if r.bodyClosed {
+		return nil
 	}
+	r.bodyClosed = true
+
+	return writeChunkedTrailer(r.w, r.Trailer)
+}
 
-	resp := Response{
-		Headers: make(Headers),
-		w:       conn,
+func (r *Response) StatusCode(code int) {
+	r.statusCode = code
+}
+
+func main() {
+	mux := NewServeMux()
+	mux.HandleFunc("GET /hello/{name}", demoHandler)
+
+	srv := &Server{
+		Addr:           ":8080",
+		Handler:        mux,
+		ReadTimeout:    10 * time.Second,
+		WriteTimeout:   10 * time.Second,
+		IdleTimeout:    60 * time.Second,
+		MaxLineLength:  8 * 1024,
+		MaxValueLength: 8 * 1024,
+		MaxHeaderLines: 100,
 	}
 
+	log.Fatal(srv.ListenAndServe())
+}
+
+func demoHandler(resp *Response, req *Request) {
 	resp.Headers.Set("Content-Type", "application/json")
 	resp.Headers.Add("x-multi-header", "one")
 	resp.Headers.Add("x-multi-header", "two")
 	resp.StatusCode(201)
-	_, err = resp.Write([]byte("hello"))
-	if err != nil {
-		log.Fatal(err)
-	}
+	resp.Write([]byte("hello " + req.PathValue("name")))
 }
 
-func parseRequest(r io.ReadCloser) (Request, error) {
+// parseRequest reads a single HTTP request off reader using a
+// headerReader bounded by limits. reader is a *bufio.Reader rather
+// than the raw net.Conn so that, on a persistent connection, any bytes
+// buffered past the end of this request are still there for the next
+// call. w is the connection the request arrived on, used only to send
+// an interim "100 Continue" if the request has an Expect: 100-continue
+// header and the handler actually reads the body.
+func parseRequest(reader *bufio.Reader, w io.Writer, limits headerLimits) (Request, error) {
 	var req Request
 
-	reader := bufio.NewReader(r)
-
-	var l []byte
+	hr := newHeaderReader(reader, limits)
 
 	// request line
-	l, _, err := reader.ReadLine()
+	l, err := hr.ReadLine()
 	if err != nil {
 		return req, err
 	}
-	reqLineParts := strings.Split(string(l), " ")
+	reqLineParts := strings.Split(l, " ")
 	if len(reqLineParts) != 3 {
 		return req, errors.New("invalid request line")
 	}
@@ -239,24 +367,21 @@ func parseRequest(r io.ReadCloser) (Request, error) {
 	req.Url = uri
 	req.Proto = reqLineParts[2]
 
-	// headers
-	// values with comma ',' as value is not supported
-	// every commna ',' is treated as delimeter for multi-value header
-	h := make(Headers)
-	for {
-		// TODO: handle long lines
-		l, _, err = reader.ReadLine()
-		if err != nil {
-			return req, err
-		}
-
-		// CRLF line separating headers and body
-		if len(l) == 0 {
-			break
+	h, err := hr.ReadMIMEHeader()
+	if err != nil {
+		return req, err
+	}
+	req.Headers = h
+
+	if tev, ok := h["Transfer-Encoding"]; ok {
+		for _, v := range tev {
+			codings := splitHeaderList(v)
+			if len(codings) > 0 && strings.EqualFold(codings[len(codings)-1], "chunked") {
+				req.Body = newChunkedReader(reader)
+				wrapExpectContinue(&req, w)
+				return req, nil
+			}
 		}
-
-		key, value := parseHeaderLine(string(l))
-		h[key] = value
 	}
 
 	var contentLen uint64
@@ -278,16 +403,7 @@ func parseRequest(r io.ReadCloser) (Request, error) {
 		}
 	}
 
-	return req, nil
-}
+	wrapExpectContinue(&req, w)
 
-func parseHeaderLine(l string) (string, []string) {
-	parts := strings.SplitN(l, ":", 2)
-	key := strings.TrimSpace(parts[0])
-	values := strings.Split(strings.TrimSpace(parts[1]), ",")
-	for i := 0; i < len(values); i++ {
-		values[i] = strings.TrimSpace(values[i])
-	}
-
-	return key, values
+	return req, nil
 }
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"os"
+)
+
+// Form holds the parsed body of a multipart/form-data request: plain
+// field values and uploaded files, both keyed by form field name.
+type Form struct {
+	Value map[string][]string
+	File  map[string][]*FileHeader
+}
+
+// RemoveAll removes any temp files created by ParseMultipartForm for
+// file parts larger than its maxMemory. The Server calls this once a
+// request is done serving, but anyone parsing a multipart form outside
+// the Server (e.g. in a test) must call it themselves, typically via
+// defer, to avoid leaking those files. It keeps trying to remove every
+// temp file even after a failure, returning the first error seen.
+func (f *Form) RemoveAll() error {
+	var firstErr error
+	for _, fhs := range f.File {
+		for _, fh := range fhs {
+			if fh.tmpPath == "" {
+				continue
+			}
+			if err := os.Remove(fh.tmpPath); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// FileHeader describes one uploaded file from a multipart/form-data
+// request. A part is kept in memory if it's no larger than the
+// maxMemory given to ParseMultipartForm, otherwise it's spilled to a
+// temp file that Open reads from.
+type FileHeader struct {
+	Filename string
+	Headers  Headers
+	Size     int64
+
+	content []byte
+	tmpPath string
+}
+
+// Open returns a reader over the file's content. The caller must Close
+// it when done.
+func (fh *FileHeader) Open() (io.ReadCloser, error) {
+	if fh.tmpPath != "" {
+		return os.Open(fh.tmpPath)
+	}
+	return io.NopCloser(bytes.NewReader(fh.content)), nil
+}
+
+// multipartReader splits a multipart/form-data body into its parts:
+// CRLF-delimited parts separated by "--boundary" lines and a final
+// "--boundary--" line. Header lines are still read with headerReader,
+// since they're always textual, but part bodies are scanned byte by
+// byte for "\r\n--boundary" rather than reassembled a line at a time -
+// a part may be an arbitrary binary upload, and a bare LF that isn't
+// actually half of the delimiter must come back out exactly as it went
+// in.
+type multipartReader struct {
+	r        *bufio.Reader
+	hr       *headerReader
+	boundary string
+	delim    []byte
+
+	started bool
+	done    bool
+	current *multipartPart
+}
+
+func newMultipartReader(r io.Reader, boundary string) *multipartReader {
+	br := bufio.NewReader(r)
+	return &multipartReader{
+		r:        br,
+		hr:       newHeaderReader(br, headerLimits{}),
+		boundary: boundary,
+		delim:    []byte("\r\n--" + boundary),
+	}
+}
+
+// NextPart advances past the previous part (discarding any of its body
+// the caller didn't read) and returns the next one, or io.EOF once the
+// closing boundary has been seen.
+func (mr *multipartReader) NextPart() (*multipartPart, error) {
+	if mr.done {
+		return nil, io.EOF
+	}
+
+	if mr.current != nil {
+		io.Copy(io.Discard, mr.current)
+		mr.current = nil
+	}
+
+	if !mr.started {
+		mr.started = true
+		// skip any preamble before the first boundary line
+		for {
+			line, err := mr.hr.readRawLine()
+			if err != nil {
+				return nil, err
+			}
+			isBoundary, isFinal := mr.matchBoundary(line)
+			if isBoundary {
+				if isFinal {
+					mr.done = true
+					return nil, io.EOF
+				}
+				break
+			}
+		}
+	}
+
+	if mr.done {
+		return nil, io.EOF
+	}
+
+	headers, err := mr.hr.ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+
+	part := &multipartPart{mr: mr, headers: headers}
+	mr.current = part
+	return part, nil
+}
+
+func (mr *multipartReader) matchBoundary(line []byte) (isBoundary, isFinal bool) {
+	s := string(line)
+	delim := "--" + mr.boundary
+	if s == delim {
+		return true, false
+	}
+	if s == delim+"--" {
+		return true, true
+	}
+	return false, false
+}
+
+// consumeBoundaryTail reads the rest of a boundary line once mr.delim
+// ("\r\n--boundary") has already been matched, to learn whether it was
+// the final boundary ("--boundary--") or an ordinary one, and to leave
+// mr.r positioned at the start of the next part's headers (or the
+// epilogue, for the final boundary).
+func (mr *multipartReader) consumeBoundaryTail() (isFinal bool, err error) {
+	var tail []byte
+	for {
+		b, err := mr.r.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		if b == '\n' {
+			break
+		}
+		if b == '\r' {
+			continue
+		}
+		tail = append(tail, b)
+	}
+	return string(tail) == "--", nil
+}
+
+// multipartPart is a single part of a multipart/form-data body: its
+// own headers plus a Read-able body that stops at the next boundary.
+// Its body is scanned a byte at a time against mr.delim with a
+// sliding window the length of the delimiter: once the window holds
+// len(delim) unclassified bytes without matching, the oldest one can't
+// possibly be part of a delimiter starting later, so it's safe to
+// release to the caller.
+type multipartPart struct {
+	mr      *multipartReader
+	headers Headers
+
+	window []byte // most recently read bytes not yet classified
+	ready  []byte // classified body bytes waiting to be Read out
+	eof    bool
+	err    error
+}
+
+// fill reads one more byte from the underlying stream, advancing the
+// window and releasing a byte to ready, or finalizing the part (via
+// eof) once the window completes a full match of mr.delim.
+func (p *multipartPart) fill() error {
+	b, err := p.mr.r.ReadByte()
+	if err != nil {
+		return err
+	}
+
+	p.window = append(p.window, b)
+	if len(p.window) < len(p.mr.delim) {
+		return nil
+	}
+
+	if bytes.Equal(p.window, p.mr.delim) {
+		isFinal, err := p.mr.consumeBoundaryTail()
+		if err != nil {
+			return err
+		}
+		if isFinal {
+			p.mr.done = true
+		}
+		p.eof = true
+		p.window = nil
+		return nil
+	}
+
+	p.ready = append(p.ready, p.window[0])
+	p.window = p.window[1:]
+	return nil
+}
+
+func (p *multipartPart) FormName() string {
+	_, params, err := mime.ParseMediaType(p.headers.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	return params["name"]
+}
+
+func (p *multipartPart) FileName() string {
+	_, params, err := mime.ParseMediaType(p.headers.Get("Content-Disposition"))
+	if err != nil {
+		return ""
+	}
+	return params["filename"]
+}
+
+func (p *multipartPart) Read(buf []byte) (int, error) {
+	if p.err != nil {
+		return 0, p.err
+	}
+
+	for len(p.ready) == 0 && !p.eof {
+		if err := p.fill(); err != nil {
+			p.err = err
+			return 0, err
+		}
+	}
+
+	if len(p.ready) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(buf, p.ready)
+	p.ready = p.ready[n:]
+	return n, nil
+}
+
+// newFileHeader reads part's body, keeping it in memory if it's no
+// larger than maxMemory and otherwise spilling it to a temp file.
+func newFileHeader(part *multipartPart, filename string, maxMemory int64) (*FileHeader, error) {
+	fh := &FileHeader{Filename: filename, Headers: part.headers}
+
+	data, err := io.ReadAll(io.LimitReader(part, maxMemory+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) <= maxMemory {
+		fh.content = data
+		fh.Size = int64(len(data))
+		return fh, nil
+	}
+
+	tmp, err := os.CreateTemp("", "httpg-multipart-*")
+	if err != nil {
+		return nil, err
+	}
+	defer tmp.Close()
+
+	n, err := tmp.Write(data)
+	size := int64(n)
+	if err != nil {
+		return nil, err
+	}
+
+	written, err := io.Copy(tmp, part)
+	if err != nil {
+		return nil, err
+	}
+	size += written
+
+	fh.tmpPath = tmp.Name()
+	fh.Size = size
+	return fh, nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMultipartPartPreservesBareLF reproduces a corruption bug in the
+// old line-based part reader: it unconditionally re-inserted "\r\n"
+// between reconstructed lines, turning any bare LF in a binary upload
+// (routine in images, archives, anything non-text) into a CRLF.
+func TestMultipartPartPreservesBareLF(t *testing.T) {
+	want := "AAA\nBBB"
+	body := "--XYZ\r\n" +
+		"Content-Disposition: form-data; name=\"file1\"; filename=\"a.bin\"\r\n" +
+		"Content-Type: application/octet-stream\r\n" +
+		"\r\n" +
+		want + "\r\n" +
+		"--XYZ--\r\n"
+
+	req := &Request{
+		Method:  "POST",
+		Headers: Headers{"Content-Type": []string{"multipart/form-data; boundary=XYZ"}},
+		Body:    strings.NewReader(body),
+	}
+
+	if err := req.ParseMultipartForm(1 << 20); err != nil {
+		t.Fatal(err)
+	}
+	defer req.MultipartForm.RemoveAll()
+
+	fhs := req.MultipartForm.File["file1"]
+	if len(fhs) != 1 {
+		t.Fatalf("expected one file1 part, got %+v", fhs)
+	}
+
+	f, err := fhs[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	got, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestFormRemoveAllDeletesTempFiles(t *testing.T) {
+	big := strings.Repeat("x", 100)
+	body := "--XYZ\r\n" +
+		"Content-Disposition: form-data; name=\"file1\"; filename=\"a.txt\"\r\n" +
+		"Content-Type: text/plain\r\n" +
+		"\r\n" +
+		big + "\r\n" +
+		"--XYZ--\r\n"
+
+	req := &Request{
+		Method:  "POST",
+		Headers: Headers{"Content-Type": []string{"multipart/form-data; boundary=XYZ"}},
+		Body:    strings.NewReader(body),
+	}
+
+	// maxMemory smaller than the part forces it to spill to a temp file.
+	if err := req.ParseMultipartForm(10); err != nil {
+		t.Fatal(err)
+	}
+
+	fhs := req.MultipartForm.File["file1"]
+	if len(fhs) != 1 || fhs[0].tmpPath == "" {
+		t.Fatalf("expected file1 to have spilled to a temp file, got %+v", fhs)
+	}
+	tmpPath := fhs[0].tmpPath
+
+	if _, err := os.Stat(tmpPath); err != nil {
+		t.Fatalf("temp file should exist before RemoveAll: %v", err)
+	}
+
+	if err := req.MultipartForm.RemoveAll(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(tmpPath); !os.IsNotExist(err) {
+		t.Fatalf("temp file should be gone after RemoveAll, stat err = %v", err)
+	}
+}
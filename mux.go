@@ -0,0 +1,165 @@
+package main
+
+import (
+	"strings"
+	"sync"
+)
+
+// Handler responds to an HTTP request.
+type Handler interface {
+	ServeHTTP(resp *Response, req *Request)
+}
+
+// ServeHTTP calls f(resp, req), letting an ordinary function be used
+// as a Handler.
+func (f HandlerFunc) ServeHTTP(resp *Response, req *Request) {
+	f(resp, req)
+}
+
+// muxEntry is one registered route. A pattern is optionally prefixed
+// with a method ("GET /items/{id}"); a path ending in "/" is a subtree
+// that also matches anything below it, otherwise the path must match
+// exactly. A "{name}" path segment captures that segment, retrievable
+// via Request.PathValue.
+type muxEntry struct {
+	method    string // "" matches any method
+	segments  []string
+	isSubtree bool
+	handler   Handler
+}
+
+// ServeMux is an HTTP request multiplexer. It matches the URL of each
+// incoming request against a list of registered patterns and calls the
+// handler for the pattern that most closely matches the URL.
+type ServeMux struct {
+	mu      sync.RWMutex
+	entries []*muxEntry
+}
+
+// NewServeMux allocates and returns a new ServeMux.
+func NewServeMux() *ServeMux {
+	return &ServeMux{}
+}
+
+// Handle registers handler for the given pattern.
+func (mux *ServeMux) Handle(pattern string, handler Handler) {
+	method, path := splitPattern(pattern)
+	if path == "" {
+		path = "/"
+	}
+
+	e := &muxEntry{
+		method:    method,
+		isSubtree: strings.HasSuffix(path, "/"),
+		handler:   handler,
+	}
+	if trimmed := strings.Trim(path, "/"); trimmed != "" {
+		e.segments = strings.Split(trimmed, "/")
+	}
+
+	mux.mu.Lock()
+	defer mux.mu.Unlock()
+	mux.entries = append(mux.entries, e)
+}
+
+// HandleFunc registers the handler function for the given pattern.
+func (mux *ServeMux) HandleFunc(pattern string, handler HandlerFunc) {
+	mux.Handle(pattern, handler)
+}
+
+// ServeHTTP dispatches the request to the handler whose pattern most
+// closely matches req.Url.Path, or writes a 404 if none match.
+func (mux *ServeMux) ServeHTTP(resp *Response, req *Request) {
+	h, pathValues := mux.handler(req)
+	if h == nil {
+		resp.StatusCode(404)
+		resp.Write([]byte("404 page not found"))
+		return
+	}
+
+	req.pathValues = pathValues
+	h.ServeHTTP(resp, req)
+}
+
+func (mux *ServeMux) handler(req *Request) (Handler, map[string]string) {
+	reqSegs := splitPath(req.Url.Path)
+
+	mux.mu.RLock()
+	defer mux.mu.RUnlock()
+
+	var best *muxEntry
+	var bestParams map[string]string
+	for _, e := range mux.entries {
+		if e.method != "" && !strings.EqualFold(e.method, req.Method) {
+			continue
+		}
+
+		params, ok := e.match(reqSegs)
+		if !ok {
+			continue
+		}
+
+		if best == nil || e.specificity() > best.specificity() {
+			best = e
+			bestParams = params
+		}
+	}
+
+	if best == nil {
+		return nil, nil
+	}
+	return best.handler, bestParams
+}
+
+// specificity ranks exact-path entries above subtree ones, and longer
+// patterns above shorter ones, so the most specific registered route
+// wins regardless of registration order.
+func (e *muxEntry) specificity() int {
+	score := len(e.segments)
+	if !e.isSubtree {
+		score += 1 << 16
+	}
+	return score
+}
+
+func (e *muxEntry) match(reqSegs []string) (map[string]string, bool) {
+	if e.isSubtree {
+		if len(reqSegs) < len(e.segments) {
+			return nil, false
+		}
+	} else if len(reqSegs) != len(e.segments) {
+		return nil, false
+	}
+
+	var params map[string]string
+	for i, seg := range e.segments {
+		if strings.HasPrefix(seg, "{") && strings.HasSuffix(seg, "}") {
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[seg[1:len(seg)-1]] = reqSegs[i]
+			continue
+		}
+
+		if seg != reqSegs[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+func splitPattern(pattern string) (method, path string) {
+	if i := strings.IndexByte(pattern, ' '); i >= 0 {
+		return pattern[:i], strings.TrimSpace(pattern[i+1:])
+	}
+	return "", pattern
+}
+
+func splitPath(path string) []string {
+	trimmed := strings.Trim(path, "/")
+	if trimmed == "" {
+		return nil
+	}
+	return strings.Split(trimmed, "/")
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestRequest(method, path string) *Request {
+	return &Request{Method: method, Url: &url.URL{Path: path}, Proto: "HTTP/1.1"}
+}
+
+func TestServeMuxExactBeatsSubtree(t *testing.T) {
+	mux := NewServeMux()
+
+	var matched string
+	mux.HandleFunc("/items/", func(resp *Response, req *Request) { matched = "subtree" })
+	mux.HandleFunc("/items/special", func(resp *Response, req *Request) { matched = "exact" })
+
+	req := newTestRequest("GET", "/items/special")
+	mux.ServeHTTP(&Response{Headers: make(Headers), w: &bytes.Buffer{}}, req)
+
+	if matched != "exact" {
+		t.Fatalf("matched = %q, want %q", matched, "exact")
+	}
+}
+
+func TestServeMuxSubtreeMatchesNested(t *testing.T) {
+	mux := NewServeMux()
+
+	var matched string
+	mux.HandleFunc("/items/", func(resp *Response, req *Request) { matched = "subtree" })
+
+	req := newTestRequest("GET", "/items/1/edit")
+	mux.ServeHTTP(&Response{Headers: make(Headers), w: &bytes.Buffer{}}, req)
+
+	if matched != "subtree" {
+		t.Fatalf("matched = %q, want %q", matched, "subtree")
+	}
+}
+
+func TestServeMuxLongerPatternWinsOverShorterSubtree(t *testing.T) {
+	mux := NewServeMux()
+
+	var matched string
+	mux.HandleFunc("/items/", func(resp *Response, req *Request) { matched = "items" })
+	mux.HandleFunc("/items/special/", func(resp *Response, req *Request) { matched = "items-special" })
+
+	req := newTestRequest("GET", "/items/special/extra")
+	mux.ServeHTTP(&Response{Headers: make(Headers), w: &bytes.Buffer{}}, req)
+
+	if matched != "items-special" {
+		t.Fatalf("matched = %q, want %q", matched, "items-special")
+	}
+}
+
+func TestServeMuxPathValueCapture(t *testing.T) {
+	mux := NewServeMux()
+
+	var gotID string
+	mux.HandleFunc("GET /items/{id}", func(resp *Response, req *Request) {
+		gotID = req.PathValue("id")
+	})
+
+	req := newTestRequest("GET", "/items/42")
+	mux.ServeHTTP(&Response{Headers: make(Headers), w: &bytes.Buffer{}}, req)
+
+	if gotID != "42" {
+		t.Fatalf("PathValue(\"id\") = %q, want %q", gotID, "42")
+	}
+}
+
+func TestServeMuxMethodFiltering(t *testing.T) {
+	mux := NewServeMux()
+
+	var calledMethod string
+	mux.HandleFunc("GET /items", func(resp *Response, req *Request) { calledMethod = "GET" })
+	mux.HandleFunc("POST /items", func(resp *Response, req *Request) { calledMethod = "POST" })
+
+	req := newTestRequest("POST", "/items")
+	mux.ServeHTTP(&Response{Headers: make(Headers), w: &bytes.Buffer{}}, req)
+
+	if calledMethod != "POST" {
+		t.Fatalf("calledMethod = %q, want %q", calledMethod, "POST")
+	}
+}
+
+func TestServeMuxNoMatchWrites404(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("GET /items", func(resp *Response, req *Request) {
+		t.Fatal("handler should not have been called")
+	})
+
+	var buf bytes.Buffer
+	req := newTestRequest("GET", "/nope")
+	mux.ServeHTTP(&Response{Headers: make(Headers), w: &buf}, req)
+
+	if !strings.Contains(buf.String(), " 404 ") {
+		t.Fatalf("response = %q, want a 404 status line", buf.String())
+	}
+}
+
+func TestServeMuxMethodMismatchFallsThroughTo404(t *testing.T) {
+	mux := NewServeMux()
+	mux.HandleFunc("GET /items", func(resp *Response, req *Request) {
+		t.Fatal("handler should not have been called")
+	})
+
+	var buf bytes.Buffer
+	req := newTestRequest("DELETE", "/items")
+	mux.ServeHTTP(&Response{Headers: make(Headers), w: &buf}, req)
+
+	if !strings.Contains(buf.String(), " 404 ") {
+		t.Fatalf("response = %q, want a 404 status line", buf.String())
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestResponseWriteChunkedTerminatesOnce(t *testing.T) {
+	var buf bytes.Buffer
+	resp := &Response{Headers: make(Headers), w: &buf}
+
+	if _, err := resp.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+
+	// A handler that also calls Close after Write (as the Server does)
+	// must not write the "0\r\n\r\n" terminator a second time.
+	if err := resp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(buf.String(), "0\r\n\r\n"); n != 1 {
+		t.Fatalf("expected exactly one chunk terminator, got %d in: %q", n, buf.String())
+	}
+}
+
+func TestResponseMultipleWritesStayInOneBody(t *testing.T) {
+	var buf bytes.Buffer
+	resp := &Response{Headers: make(Headers), w: &buf}
+
+	// A handler calling Write more than once (completely natural, since
+	// *Response satisfies io.Writer) must not have the body terminated
+	// after the first call - only Close should do that.
+	if _, err := resp.Write([]byte("hello ")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := resp.Write([]byte("world")); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if n := strings.Count(out, "0\r\n\r\n"); n != 1 {
+		t.Fatalf("expected exactly one chunk terminator, got %d in: %q", n, out)
+	}
+	if !strings.HasSuffix(out, "5\r\nworld\r\n0\r\n\r\n") {
+		t.Fatalf("expected the terminator only after both chunks, got: %q", out)
+	}
+}
+
+func TestResponseBodyWriterThenCloseTerminatesOnce(t *testing.T) {
+	var buf bytes.Buffer
+	resp := &Response{Headers: make(Headers), w: &buf}
+
+	w := resp.BodyWriter()
+	w.Write([]byte("chunk-one"))
+	w.Write([]byte("chunk-two"))
+
+	if err := resp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := resp.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(buf.String(), "0\r\n\r\n"); n != 1 {
+		t.Fatalf("expected exactly one chunk terminator, got %d in: %q", n, buf.String())
+	}
+}
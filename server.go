@@ -0,0 +1,162 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"strings"
+	"time"
+)
+
+// HandlerFunc is an adapter allowing an ordinary function to be used
+// as a Handler.
+type HandlerFunc func(resp *Response, req *Request)
+
+// Server holds the configuration for running an HTTP server. The zero
+// value is a valid Server listening on ":http" equivalent semantics of
+// net/http, except Addr must be set since there's no default here yet.
+type Server struct {
+	Addr    string
+	Handler Handler
+
+	// ReadTimeout bounds how long reading a request (request line,
+	// headers and body) may take.
+	ReadTimeout time.Duration
+	// WriteTimeout bounds how long writing the response may take.
+	WriteTimeout time.Duration
+	// IdleTimeout bounds how long a keep-alive connection may sit idle
+	// waiting for the next request. Falls back to ReadTimeout when 0.
+	IdleTimeout time.Duration
+
+	// MaxLineLength caps the length of the request line and of any
+	// single (unfolded) header line. 0 means no limit.
+	MaxLineLength int
+	// MaxValueLength caps the length of a header value once obs-fold
+	// continuations have been joined into it. 0 means no limit.
+	MaxValueLength int
+	// MaxHeaderLines caps the number of header lines a request may
+	// have. 0 means no limit.
+	MaxHeaderLines int
+}
+
+// ListenAndServe listens on s.Addr and serves each accepted connection
+// in its own goroutine until Accept returns an error.
+func (s *Server) ListenAndServe() error {
+	ln, err := net.Listen("tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return err
+		}
+
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn runs the connection lifecycle: parse a request, dispatch
+// it to the handler, and, as long as the request wants to stay alive,
+// loop and parse the next one off the same connection. Any per-request
+// error just closes this connection instead of taking down the server.
+func (s *Server) serveConn(conn net.Conn) {
+	hijacked := false
+	defer func() {
+		if !hijacked {
+			conn.Close()
+		}
+	}()
+
+	reader := bufio.NewReader(conn)
+
+	first := true
+	for {
+		readTimeout := s.ReadTimeout
+		if !first && s.IdleTimeout > 0 {
+			readTimeout = s.IdleTimeout
+		}
+		if readTimeout > 0 {
+			conn.SetReadDeadline(time.Now().Add(readTimeout))
+		}
+		first = false
+
+		req, err := parseRequest(reader, conn, headerLimits{
+			maxLineLength:  s.MaxLineLength,
+			maxValueLength: s.MaxValueLength,
+			maxHeaderLines: s.MaxHeaderLines,
+		})
+		if err != nil {
+			return
+		}
+
+		if s.WriteTimeout > 0 {
+			conn.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+		}
+
+		resp := &Response{
+			Headers:       make(Headers),
+			w:             conn,
+			conn:          conn,
+			reader:        reader,
+			continueState: req.continueState,
+		}
+
+		if s.Handler != nil {
+			s.Handler.ServeHTTP(resp, &req)
+		}
+
+		if resp.hijacked {
+			hijacked = true
+			return
+		}
+
+		if req.MultipartForm != nil {
+			req.MultipartForm.RemoveAll()
+		}
+
+		if err := resp.Close(); err != nil {
+			return
+		}
+
+		// A handler that didn't read the body to EOF would otherwise
+		// leave its remaining bytes in reader, where they'd be spliced
+		// into the next request parsed off this connection.
+		if req.Body != nil {
+			if _, err := io.Copy(io.Discard, req.Body); err != nil {
+				return
+			}
+		}
+
+		if !keepAlive(&req) {
+			return
+		}
+	}
+}
+
+// keepAlive decides whether the connection the request arrived on
+// should be reused for another request, per the HTTP/1.0 and HTTP/1.1
+// Connection header semantics.
+func keepAlive(req *Request) bool {
+	// The handler wrote a final status without ever reading the body of
+	// an Expect: 100-continue request, so no 100 Continue was sent and
+	// the client may still be about to write that body onto this
+	// connection. Per RFC 7231 §5.1.1, close rather than risk it
+	// landing in front of the next request.
+	if cs := req.continueState; cs != nil && cs.responded && !cs.sent {
+		return false
+	}
+
+	if cv, ok := req.Headers["Connection"]; ok && len(cv) > 0 {
+		switch strings.ToLower(cv[len(cv)-1]) {
+		case "close":
+			return false
+		case "keep-alive":
+			return true
+		}
+	}
+
+	return req.Proto == "HTTP/1.1"
+}